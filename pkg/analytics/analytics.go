@@ -0,0 +1,490 @@
+package analytics
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+)
+
+const (
+	sanitize_replacement_string = "***"
+
+	// defaultEntropyMinLength and defaultEntropyMinEntropy are deliberately
+	// higher than the bare minimum needed to catch typical credentials, since
+	// ordinary non-secret values such as long file paths or git commit SHAs
+	// are made up of the same credential alphabets this package looks for and
+	// can clear much lower bars than these. They can't close that gap
+	// entirely (a SHA and a random hex token are indistinguishable by
+	// length/entropy alone); see SetEntropyDetectionEnabled for the trade-off
+	// this leaves callers with.
+	defaultEntropyMinLength  = 32
+	defaultEntropyMinEntropy = 3.5
+)
+
+// sensitiveFieldNames are the (lowercase) substrings that mark a JSON field,
+// or a CLI flag name inside an argument list, as sensitive. Besides the
+// generic credential-ish terms, it includes a handful of specific flag names
+// used by IaC integrations (Terraform Cloud, Azure backends) that don't
+// contain any of the generic terms themselves.
+var sensitiveFieldNames = []string{
+	"key",
+	"token",
+	"secret",
+	"password",
+	"passw",
+	"username",
+	"user",
+	"tfc-token",
+	"azurerm-account-key",
+	"fetch-tfstate-headers",
+}
+
+// ciEnvironmentVariables are environment variables whose presence indicates
+// the CLI is running inside a CI environment.
+var ciEnvironmentVariables = []string{
+	"CI",
+	"CIRCLECI",
+	"TRAVIS",
+	"JENKINS_URL",
+	"GITHUB_ACTIONS",
+	"GITLAB_CI",
+	"BITBUCKET_BUILD_NUMBER",
+	"TEAMCITY_VERSION",
+}
+
+// entropyCharsetPattern restricts SanitizeHighEntropyValues to strings made up
+// of likely-credential alphabets (base64, base64url, hex, JWT's dot-separated
+// segments), so ordinary prose (which contains spaces and punctuation) is
+// never considered for entropy-based redaction.
+var entropyCharsetPattern = regexp.MustCompile(`^[A-Za-z0-9+/_=.-]+$`)
+
+// Analytics collects CLI usage data and turns it into a sanitized HTTP
+// request that can be submitted to the Snyk analytics endpoint.
+type Analytics interface {
+	SetCmdArguments(args []string)
+	AddError(err error)
+	SetVersion(version string)
+	SetOrg(org string)
+	SetApiUrl(url string)
+	SetIntegration(name string, version string)
+	AddHeader(headerProvider func() http.Header)
+	IsCiEnvironment() bool
+
+	// SetEntropyDetectionEnabled toggles the entropy-based redaction pass
+	// that runs in addition to the key-based one. It is enabled by default,
+	// so that a secret stored under a benign key (e.g. "details") is still
+	// caught. The trade-off is false positives: ordinary values such as long
+	// file paths or git commit SHAs are made up of the same alphabets (hex,
+	// base64-ish) as genuine credentials and can clear the default length/
+	// entropy bars too, in which case they are silently stripped with no
+	// indication to the operator that it happened. Callers whose payloads
+	// commonly contain such values should disable this pass, or narrow it via
+	// SetEntropyThresholds, rather than rely on the defaults.
+	SetEntropyDetectionEnabled(enabled bool)
+	// SetEntropyThresholds overrides the minimum string length and minimum
+	// Shannon entropy (in bits) a value must have before it is considered a
+	// likely credential by the entropy-based redaction pass.
+	SetEntropyThresholds(minLength int, minEntropy float64)
+
+	// SetClientCertificate configures a client (mutual TLS) certificate to
+	// present when submitting analytics, for deployments that sit behind an
+	// mTLS-terminating proxy. The bearer token configured via AddHeader, if
+	// any, continues to be sent alongside it.
+	SetClientCertificate(certPEM []byte, keyPEM []byte) error
+	// SetClientCertificateFromFile is like SetClientCertificate, but reloads
+	// the certificate and key from disk on every connection, so a
+	// long-running process can pick up a rotated certificate without
+	// restarting.
+	SetClientCertificateFromFile(certFile string, keyFile string) error
+	// SetRootCAs overrides the pool of CAs used to verify the analytics
+	// endpoint's server certificate, for deployments behind a proxy with a
+	// private CA.
+	SetRootCAs(pool *x509.CertPool)
+	// GetHTTPClient returns the http.Client that Send uses to submit
+	// analytics, configured with the client certificate and root CA pool set
+	// via SetClientCertificate(FromFile)/SetRootCAs, if any.
+	GetHTTPClient() *http.Client
+
+	GetRequest() (*http.Request, error)
+	// Send builds the analytics request via GetRequest and submits it using
+	// GetHTTPClient.
+	Send(ctx context.Context) (*http.Response, error)
+}
+
+type analyticsImpl struct {
+	cmdArguments       []string
+	errors             []error
+	version            string
+	org                string
+	apiUrl             string
+	integrationName    string
+	integrationVersion string
+	headerProviders    []func() http.Header
+
+	entropyDetectionEnabled bool
+	entropyMinLength        int
+	entropyMinEntropy       float64
+
+	clientCertificate *tls.Certificate
+	clientCertFile    string
+	clientKeyFile     string
+	rootCAs           *x509.CertPool
+}
+
+// New creates a new, empty Analytics instance.
+func New() Analytics {
+	return &analyticsImpl{
+		entropyDetectionEnabled: true,
+		entropyMinLength:        defaultEntropyMinLength,
+		entropyMinEntropy:       defaultEntropyMinEntropy,
+	}
+}
+
+func (a *analyticsImpl) SetCmdArguments(args []string) { a.cmdArguments = args }
+func (a *analyticsImpl) AddError(err error)            { a.errors = append(a.errors, err) }
+func (a *analyticsImpl) SetVersion(version string)     { a.version = version }
+func (a *analyticsImpl) SetOrg(org string)             { a.org = org }
+func (a *analyticsImpl) SetApiUrl(apiUrl string)       { a.apiUrl = apiUrl }
+
+func (a *analyticsImpl) SetIntegration(name string, version string) {
+	a.integrationName = name
+	a.integrationVersion = version
+}
+
+func (a *analyticsImpl) AddHeader(headerProvider func() http.Header) {
+	a.headerProviders = append(a.headerProviders, headerProvider)
+}
+
+func (a *analyticsImpl) SetEntropyDetectionEnabled(enabled bool) {
+	a.entropyDetectionEnabled = enabled
+}
+
+func (a *analyticsImpl) SetEntropyThresholds(minLength int, minEntropy float64) {
+	a.entropyMinLength = minLength
+	a.entropyMinEntropy = minEntropy
+}
+
+func (a *analyticsImpl) SetClientCertificate(certPEM []byte, keyPEM []byte) error {
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return fmt.Errorf("failed to load client certificate: %w", err)
+	}
+
+	a.clientCertificate = &cert
+	a.clientCertFile = ""
+	a.clientKeyFile = ""
+	return nil
+}
+
+func (a *analyticsImpl) SetClientCertificateFromFile(certFile string, keyFile string) error {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load client certificate from '%s': %w", certFile, err)
+	}
+
+	a.clientCertificate = &cert
+	a.clientCertFile = certFile
+	a.clientKeyFile = keyFile
+	return nil
+}
+
+func (a *analyticsImpl) SetRootCAs(pool *x509.CertPool) {
+	a.rootCAs = pool
+}
+
+func (a *analyticsImpl) GetHTTPClient() *http.Client {
+	if a.clientCertificate == nil && a.rootCAs == nil {
+		return http.DefaultClient
+	}
+
+	tlsConfig := &tls.Config{RootCAs: a.rootCAs}
+
+	if len(a.clientCertFile) > 0 && len(a.clientKeyFile) > 0 {
+		certFile, keyFile := a.clientCertFile, a.clientKeyFile
+		tlsConfig.GetClientCertificate = func(_ *tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+			if err != nil {
+				return nil, fmt.Errorf("failed to reload client certificate from '%s': %w", certFile, err)
+			}
+			return &cert, nil
+		}
+	} else if a.clientCertificate != nil {
+		tlsConfig.Certificates = []tls.Certificate{*a.clientCertificate}
+	}
+
+	return &http.Client{Transport: &http.Transport{TLSClientConfig: tlsConfig}}
+}
+
+func (a *analyticsImpl) IsCiEnvironment() bool {
+	for _, name := range ciEnvironmentVariables {
+		if len(os.Getenv(name)) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+type analyticsPayload struct {
+	Version            string   `json:"version"`
+	Org                string   `json:"org,omitempty"`
+	Args               []string `json:"args"`
+	Errors             []string `json:"errors,omitempty"`
+	IntegrationName    string   `json:"integrationName,omitempty"`
+	IntegrationVersion string   `json:"integrationVersion,omitempty"`
+	Ci                 bool     `json:"ci"`
+}
+
+func (a *analyticsImpl) GetRequest() (*http.Request, error) {
+	errorMessages := make([]string, 0, len(a.errors))
+	for _, err := range a.errors {
+		errorMessages = append(errorMessages, err.Error())
+	}
+
+	payload := analyticsPayload{
+		Version:            a.version,
+		Org:                a.org,
+		Args:               a.cmdArguments,
+		Errors:             errorMessages,
+		IntegrationName:    a.integrationName,
+		IntegrationVersion: a.integrationVersion,
+		Ci:                 a.IsCiEnvironment(),
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal analytics payload: %w", err)
+	}
+
+	body, err = SanitizeValuesByKey(sensitiveFieldNames, sanitize_replacement_string, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sanitize analytics payload: %w", err)
+	}
+
+	if a.entropyDetectionEnabled {
+		body, err = SanitizeHighEntropyValues(body, a.entropyMinLength, a.entropyMinEntropy)
+		if err != nil {
+			return nil, fmt.Errorf("failed to sanitize analytics payload: %w", err)
+		}
+	}
+
+	requestUrl := strings.TrimSuffix(a.apiUrl, "/") + "/v1/analytics/cli"
+	if len(a.org) > 0 {
+		requestUrl += "?org=" + url.QueryEscape(a.org)
+	}
+
+	request, err := http.NewRequest(http.MethodPost, requestUrl, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Content-Type", "application/json")
+
+	for _, headerProvider := range a.headerProviders {
+		for key, values := range headerProvider() {
+			for _, value := range values {
+				request.Header.Add(key, value)
+			}
+		}
+	}
+
+	return request, nil
+}
+
+func (a *analyticsImpl) Send(ctx context.Context) (*http.Response, error) {
+	request, err := a.GetRequest()
+	if err != nil {
+		return nil, err
+	}
+
+	return a.GetHTTPClient().Do(request.WithContext(ctx))
+}
+
+// SanitizeValuesByKey walks input as a JSON document and replaces every
+// string value whose key name contains (case-insensitively) one of the
+// entries in filter with replacement. String elements inside arrays are
+// additionally scanned as "--flag=value" / "--flag value" CLI argument pairs
+// and sanitized against the same filter, independent of the name of the
+// array's own key.
+func SanitizeValuesByKey(filter []string, replacement string, input []byte) ([]byte, error) {
+	var parsed interface{}
+	if err := json.Unmarshal(input, &parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse input for sanitization: %w", err)
+	}
+
+	return json.Marshal(sanitizeByKey(filter, replacement, parsed))
+}
+
+func matchesFilter(filter []string, name string) bool {
+	name = strings.ToLower(strings.TrimLeft(name, "-"))
+	for _, candidate := range filter {
+		if strings.Contains(name, strings.ToLower(candidate)) {
+			return true
+		}
+	}
+	return false
+}
+
+func sanitizeByKey(filter []string, replacement string, value interface{}) interface{} {
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		for key, fieldValue := range typed {
+			if _, ok := fieldValue.(string); ok && matchesFilter(filter, key) {
+				typed[key] = replacement
+				continue
+			}
+			typed[key] = sanitizeByKey(filter, replacement, fieldValue)
+		}
+		return typed
+	case []interface{}:
+		return sanitizeArgsArray(filter, replacement, typed)
+	default:
+		return value
+	}
+}
+
+// sanitizeArgsArray treats items as a CLI argument list, redacting the value
+// half of "--flag=value" entries and, for a bare "--flag" entry with no "=",
+// the following array element.
+func sanitizeArgsArray(filter []string, replacement string, items []interface{}) []interface{} {
+	redactNext := false
+	for i, item := range items {
+		value, ok := item.(string)
+		if !ok {
+			redactNext = false
+			continue
+		}
+
+		if redactNext {
+			items[i] = replacement
+			redactNext = false
+			continue
+		}
+
+		if key, _, found := strings.Cut(value, "="); found {
+			if matchesFilter(filter, key) {
+				items[i] = key + "=" + replacement
+			}
+			continue
+		}
+
+		redactNext = matchesFilter(filter, value)
+	}
+	return items
+}
+
+// SanitizeUsername removes every occurrence of rawUserName and homeDir from
+// input, replacing them with replacement. It is used to strip the local
+// user's name and home directory (which often leak into stack traces and
+// error messages) from data before it is submitted.
+func SanitizeUsername(rawUserName string, homeDir string, replacement string, input []byte) ([]byte, error) {
+	result := string(input)
+
+	// input is JSON, where backslashes (common in Windows paths) are escaped;
+	// escape homeDir/rawUserName the same way before matching against it.
+	if len(homeDir) > 0 {
+		result = strings.ReplaceAll(result, jsonEscape(homeDir), replacement)
+	}
+
+	if len(rawUserName) > 0 {
+		result = strings.ReplaceAll(result, jsonEscape(rawUserName), replacement)
+	}
+
+	return []byte(result), nil
+}
+
+// jsonEscape returns s as it would appear inside a JSON string literal,
+// without the surrounding quotes.
+func jsonEscape(s string) string {
+	encoded, err := json.Marshal(s)
+	if err != nil {
+		return s
+	}
+	return string(encoded[1 : len(encoded)-1])
+}
+
+// SanitizeHighEntropyValues walks input as a JSON document and replaces every
+// string leaf that looks like a credential with sanitize_replacement_string, regardless of
+// the name of the key it is stored under. A string is considered a likely
+// credential if it is at least minLen bytes long, consists solely of
+// characters found in common credential alphabets (base64, base64url, hex,
+// or JWT's dot-separated segments), and has a Shannon entropy of at least
+// minEntropy bits. This complements SanitizeValuesByKey, which only catches
+// secrets stored under a recognizably sensitive key.
+//
+// Choose minLen/minEntropy carefully: values that are unambiguously not
+// secrets, such as long file paths or git commit SHAs, are drawn from the
+// same alphabets and commonly reach the same entropy as genuine credentials,
+// so this is a blunt instrument with an inherent false-positive rate. See
+// defaultEntropyMinLength/defaultEntropyMinEntropy and
+// Analytics.SetEntropyDetectionEnabled for the caller-facing version of this
+// trade-off.
+func SanitizeHighEntropyValues(input []byte, minLen int, minEntropy float64) ([]byte, error) {
+	var parsed interface{}
+	if err := json.Unmarshal(input, &parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse input for entropy sanitization: %w", err)
+	}
+
+	return json.Marshal(sanitizeHighEntropyValue(minLen, minEntropy, parsed))
+}
+
+func sanitizeHighEntropyValue(minLen int, minEntropy float64, value interface{}) interface{} {
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		for key, fieldValue := range typed {
+			typed[key] = sanitizeHighEntropyValue(minLen, minEntropy, fieldValue)
+		}
+		return typed
+	case []interface{}:
+		for i, item := range typed {
+			typed[i] = sanitizeHighEntropyValue(minLen, minEntropy, item)
+		}
+		return typed
+	case string:
+		if isLikelyCredential(typed, minLen, minEntropy) {
+			return sanitize_replacement_string
+		}
+		return typed
+	default:
+		return value
+	}
+}
+
+func isLikelyCredential(value string, minLen int, minEntropy float64) bool {
+	if len(value) < minLen {
+		return false
+	}
+	if !entropyCharsetPattern.MatchString(value) {
+		return false
+	}
+	return shannonEntropy(value) >= minEntropy
+}
+
+// shannonEntropy computes the Shannon entropy, in bits per character, of s's
+// byte distribution.
+func shannonEntropy(s string) float64 {
+	if len(s) == 0 {
+		return 0
+	}
+
+	counts := make(map[rune]int)
+	for _, r := range s {
+		counts[r]++
+	}
+
+	length := float64(len(s))
+	entropy := 0.0
+	for _, count := range counts {
+		probability := float64(count) / length
+		entropy -= probability * math.Log2(probability)
+	}
+
+	return entropy
+}
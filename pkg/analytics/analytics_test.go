@@ -1,18 +1,53 @@
 package analytics
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
 	"encoding/json"
+	"encoding/pem"
 	"fmt"
 	"io"
+	"math/big"
 	"net/http"
 	"os"
 	"os/user"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
 
+// generateSelfSignedCertPEM returns a minimal self-signed certificate and key
+// pair, PEM-encoded, for use as test fixtures.
+func generateSelfSignedCertPEM(t *testing.T) (certPEM []byte, keyPEM []byte) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+
+	template := x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "analytics-test"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	assert.Nil(t, err)
+
+	certPEM = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+
+	keyBytes, err := x509.MarshalECPrivateKey(key)
+	assert.Nil(t, err)
+	keyPEM = pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyBytes})
+
+	return certPEM, keyPEM
+}
+
 func Test_Basic(t *testing.T) {
 	testFields := []string{
 		"tfc-token",
@@ -210,3 +245,128 @@ func Test_SanitizeUsername(t *testing.T) {
 	}
 
 }
+
+func Test_SanitizeHighEntropyValues(t *testing.T) {
+	// planted secret hiding in a field whose name gives no indication it is sensitive
+	randomHexToken := "3f9a2c8b1e7d4506f8a1c2d3e4f5a6b7c8d9e0f12345678901234567890abcd"
+	randomBase64Token := "QmFzZTY0LWVuY29kZWRSYW5kb21Ub2tlbldpdGhIaWdoRW50cm9weQ=="
+
+	type sanTest struct {
+		Details            string
+		Other              string
+		TotallyPublicValue string
+	}
+
+	inputStruct := sanTest{
+		Details:            randomHexToken,
+		Other:              randomBase64Token,
+		TotallyPublicValue: "this is just a normal sentence with plenty of words in it",
+	}
+
+	input, _ := json.Marshal(inputStruct)
+
+	output, err := SanitizeHighEntropyValues(input, 20, 3.0)
+	assert.Nil(t, err, "Failed to sanitize high entropy values!")
+
+	assert.Equal(t, 0, strings.Count(string(output), randomHexToken))
+	assert.Equal(t, 0, strings.Count(string(output), randomBase64Token))
+	assert.Equal(t, 2, strings.Count(string(output), sanitize_replacement_string))
+
+	var outputStruct sanTest
+	err = json.Unmarshal(output, &outputStruct)
+	assert.Nil(t, err, "Failed to decode json object!")
+	assert.Equal(t, inputStruct.TotallyPublicValue, outputStruct.TotallyPublicValue)
+}
+
+func Test_SanitizeHighEntropyValues_DisabledBelowThresholds(t *testing.T) {
+	type sanTest struct {
+		ShortButRandom string
+	}
+
+	inputStruct := sanTest{ShortButRandom: "aB3xQ9"}
+	input, _ := json.Marshal(inputStruct)
+
+	output, err := SanitizeHighEntropyValues(input, 20, 3.0)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, strings.Count(string(output), sanitize_replacement_string))
+}
+
+// Test_GetRequest_EntropyDetectionCatchesSecretInErrorMessage proves that the
+// entropy-based pass is actually wired into GetRequest() (it runs over the
+// fully marshaled payload, not just values SanitizeValuesByKey already knows
+// to look for), using a leaked token reported as an error message - stored
+// under "errors", a field name with no indication it is sensitive, exactly
+// the case key-based redaction can't catch.
+func Test_GetRequest_EntropyDetectionCatchesSecretInErrorMessage(t *testing.T) {
+	leakedApiKey := "3f9a2c8b1e7d4506f8a1c2d3e4f5a6b7c8d9e0f12345678901234567890abcd"
+
+	analytics := New()
+	analytics.SetEntropyDetectionEnabled(true)
+	analytics.SetApiUrl("http://myapi.com")
+	analytics.AddError(fmt.Errorf("%s", leakedApiKey))
+
+	request, err := analytics.GetRequest()
+	assert.Nil(t, err)
+
+	body, err := io.ReadAll(request.Body)
+	assert.Nil(t, err)
+
+	assert.Equal(t, 0, strings.Count(string(body), leakedApiKey), "leaked secret should have been redacted by entropy detection")
+	assert.Equal(t, 1, strings.Count(string(body), sanitize_replacement_string))
+}
+
+// Test_GetRequest_EntropyDetectionDisabled confirms that disabling entropy
+// detection skips that pass, so a high-entropy token under a non-sensitive
+// key reaches the request body unredacted.
+func Test_GetRequest_EntropyDetectionDisabled(t *testing.T) {
+	leakedApiKey := "3f9a2c8b1e7d4506f8a1c2d3e4f5a6b7c8d9e0f12345678901234567890abcd"
+
+	analytics := New()
+	analytics.SetEntropyDetectionEnabled(false)
+	analytics.SetApiUrl("http://myapi.com")
+	analytics.AddError(fmt.Errorf("%s", leakedApiKey))
+
+	request, err := analytics.GetRequest()
+	assert.Nil(t, err)
+
+	body, err := io.ReadAll(request.Body)
+	assert.Nil(t, err)
+
+	assert.Equal(t, 1, strings.Count(string(body), leakedApiKey))
+}
+
+func Test_SetClientCertificate(t *testing.T) {
+	analytics := New()
+
+	err := analytics.SetClientCertificate([]byte("not a pem"), []byte("not a pem"))
+	assert.NotNil(t, err)
+
+	certPEM, keyPEM := generateSelfSignedCertPEM(t)
+	err = analytics.SetClientCertificate(certPEM, keyPEM)
+	assert.Nil(t, err)
+
+	client := analytics.GetHTTPClient()
+	assert.NotNil(t, client)
+	assert.NotEqual(t, http.DefaultClient, client)
+
+	transport, ok := client.Transport.(*http.Transport)
+	assert.True(t, ok)
+	assert.Len(t, transport.TLSClientConfig.Certificates, 1)
+}
+
+func Test_GetHTTPClient_DefaultsWithoutTLSConfig(t *testing.T) {
+	analytics := New()
+	assert.Equal(t, http.DefaultClient, analytics.GetHTTPClient())
+}
+
+func Test_SetRootCAs(t *testing.T) {
+	analytics := New()
+	pool := x509.NewCertPool()
+
+	analytics.SetRootCAs(pool)
+
+	client := analytics.GetHTTPClient()
+	transport, ok := client.Transport.(*http.Transport)
+	assert.True(t, ok)
+	assert.Equal(t, pool, transport.TLSClientConfig.RootCAs)
+}
@@ -0,0 +1,233 @@
+package localworkflows
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/snyk/go-application-framework/pkg/local_workflows/output_formats"
+	"github.com/snyk/go-application-framework/pkg/workflow"
+	"github.com/spf13/pflag"
+)
+
+var WORKFLOWID_GITHUB_UPLOAD workflow.Identifier = workflow.NewWorkflowIdentifier("github.upload")
+
+const (
+	GITHUB_UPLOAD_CONFIG_KEY_TOKEN      = "github-token"
+	GITHUB_UPLOAD_CONFIG_KEY_REPOSITORY = "github-repository"
+	GITHUB_UPLOAD_CONFIG_KEY_COMMIT     = "github-commit"
+	GITHUB_UPLOAD_CONFIG_KEY_REF        = "github-ref"
+
+	githubPollInterval = 2 * time.Second
+	githubPollTimeout  = 2 * time.Minute
+)
+
+// githubApiBaseUrl is a var rather than a const so that tests can point it at
+// a local test server.
+var githubApiBaseUrl = "https://api.github.com"
+
+// printfLogger is the subset of the workflow debug logger this file depends
+// on, so that it can be passed around without importing the concrete logger
+// type.
+type printfLogger interface {
+	Printf(format string, v ...interface{})
+}
+
+// InitGitHubUploadWorkflow initializes the github.upload workflow.
+// The github.upload workflow takes SARIF-typed workflow.Data (or Snyk finding
+// data that can be converted to SARIF) and uploads it to GitHub code scanning,
+// so that CI pipelines can surface Snyk results the same way they would
+// CodeQL's, without depending on github/codeql-action/upload-sarif.
+func InitGitHubUploadWorkflow(engine workflow.Engine) error {
+	config := pflag.NewFlagSet("github.upload", pflag.ExitOnError)
+	config.String(GITHUB_UPLOAD_CONFIG_KEY_TOKEN, "", "GitHub token used to authenticate the code scanning upload")
+	config.String(GITHUB_UPLOAD_CONFIG_KEY_REPOSITORY, "", "GitHub repository to upload results to, as owner/repo")
+	config.String(GITHUB_UPLOAD_CONFIG_KEY_COMMIT, "", "Commit SHA the results were produced for")
+	config.String(GITHUB_UPLOAD_CONFIG_KEY_REF, "", "Git ref the results were produced for, e.g. refs/heads/main")
+
+	entry, err := engine.Register(WORKFLOWID_GITHUB_UPLOAD, workflow.ConfigurationOptionsFromFlagset(config), githubUploadWorkflowEntryPoint)
+	entry.SetVisibility(false)
+
+	return err
+}
+
+type githubSarifUploadRequest struct {
+	CommitSha string `json:"commit_sha"`
+	Ref       string `json:"ref"`
+	SarifData string `json:"sarif"`
+}
+
+type githubSarifUploadResponse struct {
+	Id  string `json:"id"`
+	Url string `json:"url"`
+}
+
+type githubSarifUploadStatus struct {
+	ProcessingStatus string   `json:"processing_status"`
+	AnalysesUrl      string   `json:"analyses_url"`
+	Errors           []string `json:"errors"`
+}
+
+func githubUploadWorkflowEntryPoint(invocation workflow.InvocationContext, input []workflow.Data) (output []workflow.Data, err error) {
+	output = []workflow.Data{}
+
+	config := invocation.GetConfiguration()
+	debugLogger := invocation.GetLogger()
+
+	token := config.GetString(GITHUB_UPLOAD_CONFIG_KEY_TOKEN)
+	repository := config.GetString(GITHUB_UPLOAD_CONFIG_KEY_REPOSITORY)
+	commit := config.GetString(GITHUB_UPLOAD_CONFIG_KEY_COMMIT)
+	ref := config.GetString(GITHUB_UPLOAD_CONFIG_KEY_REF)
+
+	if len(token) == 0 || len(repository) == 0 || len(commit) == 0 || len(ref) == 0 {
+		return output, fmt.Errorf("--%s, --%s, --%s and --%s are all required to upload results to GitHub code scanning", GITHUB_UPLOAD_CONFIG_KEY_TOKEN, GITHUB_UPLOAD_CONFIG_KEY_REPOSITORY, GITHUB_UPLOAD_CONFIG_KEY_COMMIT, GITHUB_UPLOAD_CONFIG_KEY_REF)
+	}
+
+	for i := range input {
+		sarifPayload, sarifErr := sarifPayloadFor(input[i])
+		if sarifErr != nil {
+			return output, sarifErr
+		}
+
+		encodedPayload, gzipErr := gzipAndBase64(sarifPayload)
+		if gzipErr != nil {
+			return output, fmt.Errorf("failed to compress SARIF payload: %w", gzipErr)
+		}
+
+		uploadId, uploadErr := uploadSarifToGithub(repository, token, commit, ref, encodedPayload)
+		if uploadErr != nil {
+			return output, uploadErr
+		}
+
+		debugLogger.Printf("Uploaded '%s' to GitHub code scanning as '%s', polling for processing status\n", input[i].GetIdentifier().String(), uploadId)
+
+		if pollErr := pollGithubUploadStatus(repository, token, uploadId, debugLogger); pollErr != nil {
+			return output, pollErr
+		}
+	}
+
+	return output, nil
+}
+
+// sarifPayloadFor returns the SARIF representation of data, converting Snyk
+// finding data via output_formats if it isn't already SARIF.
+func sarifPayloadFor(data workflow.Data) ([]byte, error) {
+	payload, ok := data.GetPayload().([]byte)
+	if !ok {
+		return nil, fmt.Errorf("unsupported payload type for '%s'", data.GetIdentifier().String())
+	}
+
+	if strings.Contains(data.GetContentType(), OUTPUT_CONFIG_KEY_SARIF) {
+		return payload, nil
+	}
+
+	return output_formats.ConvertSnykFindingsToSarif(payload)
+}
+
+// gzipAndBase64 compresses data and base64-encodes the result, as required by
+// the GitHub code-scanning SARIF upload endpoint.
+func gzipAndBase64(data []byte) (string, error) {
+	var buffer bytes.Buffer
+	writer := gzip.NewWriter(&buffer)
+	if _, err := writer.Write(data); err != nil {
+		return "", err
+	}
+	if err := writer.Close(); err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(buffer.Bytes()), nil
+}
+
+func uploadSarifToGithub(repository string, token string, commit string, ref string, sarifPayload string) (string, error) {
+	requestBody, err := json.Marshal(githubSarifUploadRequest{
+		CommitSha: commit,
+		Ref:       ref,
+		SarifData: sarifPayload,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	url := fmt.Sprintf("%s/repos/%s/code-scanning/sarifs", githubApiBaseUrl, repository)
+	request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(requestBody))
+	if err != nil {
+		return "", err
+	}
+	setGithubHeaders(request, token)
+
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return "", fmt.Errorf("failed to upload SARIF results to GitHub: %w", err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusAccepted {
+		body, _ := io.ReadAll(response.Body)
+		return "", fmt.Errorf("failed to upload SARIF results to GitHub: unexpected status %d: %s", response.StatusCode, string(body))
+	}
+
+	var uploadResponse githubSarifUploadResponse
+	if err := json.NewDecoder(response.Body).Decode(&uploadResponse); err != nil {
+		return "", fmt.Errorf("failed to parse GitHub upload response: %w", err)
+	}
+
+	return uploadResponse.Id, nil
+}
+
+// pollGithubUploadStatus polls the GitHub code-scanning upload status endpoint
+// until the upload completes, fails, or githubPollTimeout elapses.
+func pollGithubUploadStatus(repository string, token string, uploadId string, debugLogger printfLogger) error {
+	url := fmt.Sprintf("%s/repos/%s/code-scanning/sarifs/%s", githubApiBaseUrl, repository, uploadId)
+	deadline := time.Now().Add(githubPollTimeout)
+
+	for time.Now().Before(deadline) {
+		request, err := http.NewRequest(http.MethodGet, url, nil)
+		if err != nil {
+			return err
+		}
+		setGithubHeaders(request, token)
+
+		response, err := http.DefaultClient.Do(request)
+		if err != nil {
+			return fmt.Errorf("failed to poll GitHub upload status: %w", err)
+		}
+
+		if response.StatusCode != http.StatusOK {
+			body, _ := io.ReadAll(response.Body)
+			response.Body.Close()
+			return fmt.Errorf("failed to poll GitHub upload status for '%s': unexpected status %d: %s", uploadId, response.StatusCode, string(body))
+		}
+
+		var status githubSarifUploadStatus
+		decodeErr := json.NewDecoder(response.Body).Decode(&status)
+		response.Body.Close()
+		if decodeErr != nil {
+			return fmt.Errorf("failed to parse GitHub upload status: %w", decodeErr)
+		}
+
+		switch status.ProcessingStatus {
+		case "complete":
+			return nil
+		case "failed":
+			return fmt.Errorf("GitHub failed to process SARIF upload '%s': %v", uploadId, status.Errors)
+		}
+
+		debugLogger.Printf("GitHub upload '%s' still processing ('%s'), retrying in %s\n", uploadId, status.ProcessingStatus, githubPollInterval)
+		time.Sleep(githubPollInterval)
+	}
+
+	return fmt.Errorf("timed out waiting for GitHub to process SARIF upload '%s'", uploadId)
+}
+
+func setGithubHeaders(request *http.Request, token string) {
+	request.Header.Set("Authorization", "Bearer "+token)
+	request.Header.Set("Accept", "application/vnd.github+json")
+	request.Header.Set("Content-Type", "application/json")
+}
@@ -0,0 +1,138 @@
+package localworkflows
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Note: sarifPayloadFor and githubUploadWorkflowEntryPoint itself are not
+// covered here, since they take workflow.Data / workflow.InvocationContext,
+// and this package has no fakes for those types. The networked helpers below
+// don't depend on workflow.* and are tested directly against a test server.
+
+// testPrintfLogger is a minimal printfLogger that discards its output.
+type testPrintfLogger struct{}
+
+func (testPrintfLogger) Printf(format string, v ...interface{}) {}
+
+func Test_GzipAndBase64(t *testing.T) {
+	encoded, err := gzipAndBase64([]byte(`{"hello":"world"}`))
+	assert.Nil(t, err)
+
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	assert.Nil(t, err)
+
+	reader, err := gzip.NewReader(bytes.NewReader(raw))
+	assert.Nil(t, err)
+	defer reader.Close()
+
+	decompressed, err := io.ReadAll(reader)
+	assert.Nil(t, err)
+	assert.Equal(t, `{"hello":"world"}`, string(decompressed))
+}
+
+func Test_SetGithubHeaders(t *testing.T) {
+	request, err := http.NewRequest(http.MethodGet, "https://example.com", nil)
+	assert.Nil(t, err)
+
+	setGithubHeaders(request, "my-token")
+
+	assert.Equal(t, "Bearer my-token", request.Header.Get("Authorization"))
+	assert.Equal(t, "application/vnd.github+json", request.Header.Get("Accept"))
+	assert.Equal(t, "application/json", request.Header.Get("Content-Type"))
+}
+
+func Test_UploadSarifToGithub_Success(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/repos/someOrg/someRepo/code-scanning/sarifs", r.URL.Path)
+		assert.Equal(t, "Bearer my-token", r.Header.Get("Authorization"))
+
+		var body githubSarifUploadRequest
+		assert.Nil(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, "abc123", body.CommitSha)
+		assert.Equal(t, "refs/heads/main", body.Ref)
+
+		w.WriteHeader(http.StatusAccepted)
+		_ = json.NewEncoder(w).Encode(githubSarifUploadResponse{Id: "upload-id-1", Url: "https://example.com/status"})
+	}))
+	defer server.Close()
+
+	restore := setGithubApiBaseUrlForTest(server.URL)
+	defer restore()
+
+	uploadId, err := uploadSarifToGithub("someOrg/someRepo", "my-token", "abc123", "refs/heads/main", "encoded-payload")
+	assert.Nil(t, err)
+	assert.Equal(t, "upload-id-1", uploadId)
+}
+
+func Test_UploadSarifToGithub_UnexpectedStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte("forbidden"))
+	}))
+	defer server.Close()
+
+	restore := setGithubApiBaseUrlForTest(server.URL)
+	defer restore()
+
+	_, err := uploadSarifToGithub("someOrg/someRepo", "my-token", "abc123", "refs/heads/main", "encoded-payload")
+	assert.NotNil(t, err)
+}
+
+func Test_PollGithubUploadStatus_Complete(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/repos/someOrg/someRepo/code-scanning/sarifs/upload-id-1", r.URL.Path)
+		_ = json.NewEncoder(w).Encode(githubSarifUploadStatus{ProcessingStatus: "complete"})
+	}))
+	defer server.Close()
+
+	restore := setGithubApiBaseUrlForTest(server.URL)
+	defer restore()
+
+	err := pollGithubUploadStatus("someOrg/someRepo", "my-token", "upload-id-1", testPrintfLogger{})
+	assert.Nil(t, err)
+}
+
+func Test_PollGithubUploadStatus_Failed(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(githubSarifUploadStatus{ProcessingStatus: "failed", Errors: []string{"bad sarif"}})
+	}))
+	defer server.Close()
+
+	restore := setGithubApiBaseUrlForTest(server.URL)
+	defer restore()
+
+	err := pollGithubUploadStatus("someOrg/someRepo", "my-token", "upload-id-1", testPrintfLogger{})
+	assert.NotNil(t, err)
+}
+
+func Test_PollGithubUploadStatus_UnexpectedStatusCode(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte("unauthorized"))
+	}))
+	defer server.Close()
+
+	restore := setGithubApiBaseUrlForTest(server.URL)
+	defer restore()
+
+	err := pollGithubUploadStatus("someOrg/someRepo", "my-token", "upload-id-1", testPrintfLogger{})
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "401")
+}
+
+// setGithubApiBaseUrlForTest overrides githubApiBaseUrl for the duration of a
+// test and returns a func that restores the original value.
+func setGithubApiBaseUrlForTest(url string) func() {
+	original := githubApiBaseUrl
+	githubApiBaseUrl = url
+	return func() { githubApiBaseUrl = original }
+}
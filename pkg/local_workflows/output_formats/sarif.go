@@ -0,0 +1,150 @@
+package output_formats
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+const (
+	sarifVersion = "2.1.0"
+	sarifSchema  = "https://raw.githubusercontent.com/oasis-tcs/sarif-spec/master/Schemata/sarif-schema-2.1.0.json"
+	sarifDriver  = "Snyk"
+)
+
+// SnykFinding is the minimal representation of a single Snyk finding that this
+// package knows how to translate into a SARIF result. It intentionally only
+// covers the fields required to populate a SARIF result and location.
+type SnykFinding struct {
+	RuleId    string `json:"ruleId"`
+	Level     string `json:"level"`
+	Message   string `json:"message"`
+	Path      string `json:"path"`
+	StartLine int    `json:"startLine"`
+	EndLine   int    `json:"endLine"`
+}
+
+// SnykFindings is the top level container for a set of SnykFinding entries,
+// matching the shape produced by the Snyk finding data that flows through the
+// output workflow.
+type SnykFindings struct {
+	Findings []SnykFinding `json:"findings"`
+}
+
+// Sarif is a (partial) representation of the SARIF 2.1.0 log file format,
+// covering only the fields needed to report Snyk findings to tools such as
+// GitHub code scanning.
+type Sarif struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []SarifRun `json:"runs"`
+}
+
+type SarifRun struct {
+	Tool    SarifTool     `json:"tool"`
+	Results []SarifResult `json:"results"`
+}
+
+type SarifTool struct {
+	Driver SarifDriver `json:"driver"`
+}
+
+type SarifDriver struct {
+	Name string `json:"name"`
+}
+
+type SarifResult struct {
+	RuleId    string                `json:"ruleId"`
+	Level     string                `json:"level"`
+	Message   SarifMessage          `json:"message"`
+	Locations []SarifResultLocation `json:"locations,omitempty"`
+}
+
+type SarifMessage struct {
+	Text string `json:"text"`
+}
+
+type SarifResultLocation struct {
+	PhysicalLocation SarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type SarifPhysicalLocation struct {
+	ArtifactLocation SarifArtifactLocation `json:"artifactLocation"`
+	// Region is omitted rather than zero-valued when a finding has no line
+	// information, since the SARIF 2.1.0 schema requires region.startLine and
+	// region.endLine to be >= 1 when region is present at all.
+	Region *SarifRegion `json:"region,omitempty"`
+}
+
+type SarifArtifactLocation struct {
+	Uri string `json:"uri"`
+}
+
+type SarifRegion struct {
+	StartLine int `json:"startLine"`
+	EndLine   int `json:"endLine"`
+}
+
+// ConvertSnykFindingsToSarif converts Snyk finding data (as produced by Snyk
+// products further up the workflow chain) into a SARIF 2.1.0 document so that
+// it can be consumed by tools that already know how to read SARIF, such as
+// GitHub code scanning.
+func ConvertSnykFindingsToSarif(input []byte) ([]byte, error) {
+	var findings SnykFindings
+	if err := json.Unmarshal(input, &findings); err != nil {
+		return nil, fmt.Errorf("failed to parse Snyk finding data: %w", err)
+	}
+
+	results := make([]SarifResult, 0, len(findings.Findings))
+	for _, finding := range findings.Findings {
+		results = append(results, SarifResult{
+			RuleId:    finding.RuleId,
+			Level:     finding.Level,
+			Message:   SarifMessage{Text: finding.Message},
+			Locations: sarifLocationsFor(finding),
+		})
+	}
+
+	sarif := Sarif{
+		Schema:  sarifSchema,
+		Version: sarifVersion,
+		Runs: []SarifRun{
+			{
+				Tool: SarifTool{
+					Driver: SarifDriver{Name: sarifDriver},
+				},
+				Results: results,
+			},
+		},
+	}
+
+	return json.Marshal(sarif)
+}
+
+// sarifLocationsFor builds the SARIF locations for finding, omitting the
+// location entirely when finding has no path (common for SCA/dependency
+// findings) and omitting the region when finding has no line information, so
+// that this package never emits the region.startLine/endLine of 0 the SARIF
+// 2.1.0 schema forbids (it requires both to be >= 1 when region is present).
+func sarifLocationsFor(finding SnykFinding) []SarifResultLocation {
+	if len(finding.Path) == 0 {
+		return nil
+	}
+
+	var region *SarifRegion
+	if finding.StartLine > 0 {
+		endLine := finding.EndLine
+		if endLine <= 0 {
+			endLine = finding.StartLine
+		}
+		region = &SarifRegion{StartLine: finding.StartLine, EndLine: endLine}
+	}
+
+	return []SarifResultLocation{
+		{
+			PhysicalLocation: SarifPhysicalLocation{
+				ArtifactLocation: SarifArtifactLocation{Uri: finding.Path},
+				Region:           region,
+			},
+		},
+	}
+}
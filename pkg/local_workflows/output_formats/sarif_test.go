@@ -0,0 +1,228 @@
+package output_formats
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ConvertSnykFindingsToSarif(t *testing.T) {
+	input := `{
+		"findings": [
+			{
+				"ruleId": "SNYK-JS-LODASH-1040724",
+				"level": "error",
+				"message": "Prototype Pollution in lodash",
+				"path": "package.json",
+				"startLine": 12,
+				"endLine": 12
+			}
+		]
+	}`
+
+	output, err := ConvertSnykFindingsToSarif([]byte(input))
+	assert.Nil(t, err)
+
+	var sarif Sarif
+	err = json.Unmarshal(output, &sarif)
+	assert.Nil(t, err)
+
+	assert.Equal(t, sarifVersion, sarif.Version)
+	assert.Equal(t, sarifSchema, sarif.Schema)
+	assert.Len(t, sarif.Runs, 1)
+	assert.Equal(t, "Snyk", sarif.Runs[0].Tool.Driver.Name)
+
+	assert.Len(t, sarif.Runs[0].Results, 1)
+	result := sarif.Runs[0].Results[0]
+	assert.Equal(t, "SNYK-JS-LODASH-1040724", result.RuleId)
+	assert.Equal(t, "error", result.Level)
+	assert.Equal(t, "Prototype Pollution in lodash", result.Message.Text)
+
+	assert.Len(t, result.Locations, 1)
+	location := result.Locations[0].PhysicalLocation
+	assert.Equal(t, "package.json", location.ArtifactLocation.Uri)
+	assert.Equal(t, 12, location.Region.StartLine)
+	assert.Equal(t, 12, location.Region.EndLine)
+}
+
+func Test_ConvertSnykFindingsToSarif_Empty(t *testing.T) {
+	output, err := ConvertSnykFindingsToSarif([]byte(`{"findings": []}`))
+	assert.Nil(t, err)
+
+	var sarif Sarif
+	err = json.Unmarshal(output, &sarif)
+	assert.Nil(t, err)
+	assert.Len(t, sarif.Runs, 1)
+	assert.Len(t, sarif.Runs[0].Results, 0)
+}
+
+func Test_ConvertSnykFindingsToSarif_InvalidInput(t *testing.T) {
+	_, err := ConvertSnykFindingsToSarif([]byte(`not-json`))
+	assert.NotNil(t, err)
+}
+
+// Test_ConvertSnykFindingsToSarif_NoPath covers SCA/dependency findings that
+// have no location at all: emitting "locations":[{...,"uri":""}] would still
+// be schema-valid but misleading, so the location is omitted entirely.
+func Test_ConvertSnykFindingsToSarif_NoPath(t *testing.T) {
+	input := `{
+		"findings": [
+			{
+				"ruleId": "SNYK-JS-LODASH-1040724",
+				"level": "error",
+				"message": "Prototype Pollution in lodash"
+			}
+		]
+	}`
+
+	output, err := ConvertSnykFindingsToSarif([]byte(input))
+	assert.Nil(t, err)
+	assertMatchesSarifSchemaSubset(t, output)
+
+	var sarif Sarif
+	assert.Nil(t, json.Unmarshal(output, &sarif))
+	assert.Len(t, sarif.Runs[0].Results[0].Locations, 0)
+}
+
+// Test_ConvertSnykFindingsToSarif_NoStartLine covers findings that point at a
+// path but have no specific line (e.g. a manifest-level SCA finding): the
+// SARIF 2.1.0 schema requires region.startLine/endLine to be >= 1 when region
+// is present at all, so a finding with no line information must omit region
+// rather than emit "startLine":0.
+func Test_ConvertSnykFindingsToSarif_NoStartLine(t *testing.T) {
+	input := `{
+		"findings": [
+			{
+				"ruleId": "SNYK-JS-LODASH-1040724",
+				"level": "error",
+				"message": "Prototype Pollution in lodash",
+				"path": "package.json"
+			}
+		]
+	}`
+
+	output, err := ConvertSnykFindingsToSarif([]byte(input))
+	assert.Nil(t, err)
+	assertMatchesSarifSchemaSubset(t, output)
+
+	var sarif Sarif
+	assert.Nil(t, json.Unmarshal(output, &sarif))
+	location := sarif.Runs[0].Results[0].Locations[0].PhysicalLocation
+	assert.Equal(t, "package.json", location.ArtifactLocation.Uri)
+	assert.Nil(t, location.Region)
+}
+
+// Test_ConvertSnykFindingsToSarif_MatchesSarifSchema checks the emitted
+// document against the constraints the SARIF 2.1.0 schema actually imposes
+// (https://raw.githubusercontent.com/oasis-tcs/sarif-spec/master/Schemata/sarif-schema-2.1.0.json),
+// rather than round-tripping it through this package's own Sarif struct,
+// which can't tell a schema-valid document from a schema-invalid one since it
+// defines both sides of that round trip.
+func Test_ConvertSnykFindingsToSarif_MatchesSarifSchema(t *testing.T) {
+	input := `{
+		"findings": [
+			{
+				"ruleId": "SNYK-JS-LODASH-1040724",
+				"level": "error",
+				"message": "Prototype Pollution in lodash",
+				"path": "package.json",
+				"startLine": 12,
+				"endLine": 12
+			}
+		]
+	}`
+
+	output, err := ConvertSnykFindingsToSarif([]byte(input))
+	assert.Nil(t, err)
+
+	assertMatchesSarifSchemaSubset(t, output)
+}
+
+// assertMatchesSarifSchemaSubset validates document against the subset of the
+// SARIF 2.1.0 schema's "$schema"/"version"/"runs" requirements that this
+// package's output is expected to satisfy: the required top-level properties,
+// the required properties of a run/result/location, and the enum of values
+// "level" is allowed to take.
+func assertMatchesSarifSchemaSubset(t *testing.T, document []byte) {
+	t.Helper()
+
+	var root map[string]interface{}
+	assert.Nil(t, json.Unmarshal(document, &root))
+
+	schemaValue, ok := root["$schema"].(string)
+	assert.True(t, ok, "$schema is required and must be a string")
+	assert.Equal(t, sarifSchema, schemaValue)
+
+	versionValue, ok := root["version"].(string)
+	assert.True(t, ok, "version is required and must be a string")
+	assert.Equal(t, "2.1.0", versionValue)
+
+	runs, ok := root["runs"].([]interface{})
+	assert.True(t, ok, "runs is required and must be an array")
+	assert.GreaterOrEqual(t, len(runs), 1, "runs must have at least one item")
+
+	validLevels := map[string]bool{"none": true, "note": true, "warning": true, "error": true}
+
+	for _, runValue := range runs {
+		run, ok := runValue.(map[string]interface{})
+		assert.True(t, ok, "each run must be an object")
+
+		tool, ok := run["tool"].(map[string]interface{})
+		assert.True(t, ok, "run.tool is required and must be an object")
+
+		driver, ok := tool["driver"].(map[string]interface{})
+		assert.True(t, ok, "tool.driver is required and must be an object")
+
+		driverName, ok := driver["name"].(string)
+		assert.True(t, ok, "driver.name is required and must be a string")
+		assert.NotEmpty(t, driverName)
+
+		results, ok := run["results"].([]interface{})
+		assert.True(t, ok, "run.results must be an array")
+
+		for _, resultValue := range results {
+			result, ok := resultValue.(map[string]interface{})
+			assert.True(t, ok, "each result must be an object")
+
+			message, ok := result["message"].(map[string]interface{})
+			assert.True(t, ok, "result.message is required and must be an object")
+			_, ok = message["text"].(string)
+			assert.True(t, ok, "message.text is required and must be a string")
+
+			if level, present := result["level"]; present {
+				levelValue, ok := level.(string)
+				assert.True(t, ok, "result.level must be a string")
+				assert.True(t, validLevels[levelValue], "result.level must be one of none/note/warning/error, got '%s'", levelValue)
+			}
+
+			locationsValue, present := result["locations"]
+			if !present {
+				continue
+			}
+			locations, ok := locationsValue.([]interface{})
+			assert.True(t, ok, "result.locations, when present, must be an array")
+
+			for _, locationValue := range locations {
+				location, ok := locationValue.(map[string]interface{})
+				assert.True(t, ok, "each location must be an object")
+
+				physicalLocation, ok := location["physicalLocation"].(map[string]interface{})
+				assert.True(t, ok, "location.physicalLocation must be an object")
+
+				artifactLocation, ok := physicalLocation["artifactLocation"].(map[string]interface{})
+				assert.True(t, ok, "physicalLocation.artifactLocation must be an object")
+				_, ok = artifactLocation["uri"].(string)
+				assert.True(t, ok, "artifactLocation.uri must be a string")
+
+				if region, present := physicalLocation["region"]; present {
+					regionMap, ok := region.(map[string]interface{})
+					assert.True(t, ok, "physicalLocation.region must be an object")
+					startLine, ok := regionMap["startLine"].(float64)
+					assert.True(t, ok, "region.startLine must be a number")
+					assert.GreaterOrEqual(t, startLine, float64(1), "region.startLine must be >= 1")
+				}
+			}
+		}
+	}
+}
@@ -1,7 +1,9 @@
 package localworkflows
 
 import (
+	"bytes"
 	"fmt"
+	"net/http"
 	"strings"
 
 	iUtils "github.com/snyk/go-application-framework/internal/utils"
@@ -12,10 +14,144 @@ import (
 var WORKFLOWID_OUTPUT_WORKFLOW workflow.Identifier = workflow.NewWorkflowIdentifier("output")
 
 const (
-	OUTPUT_CONFIG_KEY_JSON      = "json"
-	OUTPUT_CONFIG_KEY_JSON_FILE = "json-file-output"
+	OUTPUT_CONFIG_KEY_JSON        = "json"
+	OUTPUT_CONFIG_KEY_JSON_FILE   = "json-file-output"
+	OUTPUT_CONFIG_KEY_SARIF       = "sarif"
+	OUTPUT_CONFIG_KEY_SARIF_FILE  = "sarif-file-output"
+	OUTPUT_CONFIG_KEY_FORMAT      = "format"
+	OUTPUT_CONFIG_KEY_OUTPUT_FILE = "output-file"
+	OUTPUT_CONFIG_KEY_OUTPUT      = "output"
+	OUTPUT_CONFIG_KEY_TEXT        = "text"
 )
 
+var outputRenderers = map[string]OutputRenderer{}
+
+// OutputRenderer turns a single piece of workflow.Data into its textual
+// representation for a given output format. Built-in formats (json, sarif)
+// are registered by this package; integrators can add their own (HTML, JUnit,
+// text/template, ...) via RegisterOutputRenderer without forking the module.
+type OutputRenderer interface {
+	// MimeTypes returns the list of mime type substrings this renderer
+	// handles, e.g. []string{"json"} for "application/json".
+	MimeTypes() []string
+	// Render writes the rendered representation of data to writer.
+	Render(data workflow.Data, writer *bytes.Buffer) error
+}
+
+// RegisterOutputRenderer registers renderer for each of the mime types it
+// declares, overriding any renderer previously registered for that mime type.
+func RegisterOutputRenderer(renderer OutputRenderer) {
+	for _, mimeType := range renderer.MimeTypes() {
+		outputRenderers[mimeType] = renderer
+	}
+}
+
+func init() {
+	RegisterOutputRenderer(jsonOutputRenderer{})
+	RegisterOutputRenderer(sarifOutputRenderer{})
+}
+
+// jsonOutputRenderer is the built-in renderer for application/json payloads.
+type jsonOutputRenderer struct{}
+
+func (jsonOutputRenderer) MimeTypes() []string { return []string{OUTPUT_CONFIG_KEY_JSON} }
+
+func (jsonOutputRenderer) Render(data workflow.Data, writer *bytes.Buffer) error {
+	payload, ok := data.GetPayload().([]byte)
+	if !ok {
+		return fmt.Errorf("unsupported json payload type")
+	}
+	_, err := writer.Write(payload)
+	return err
+}
+
+// sarifOutputRenderer is the built-in renderer for application/sarif+json
+// payloads.
+type sarifOutputRenderer struct{}
+
+func (sarifOutputRenderer) MimeTypes() []string { return []string{OUTPUT_CONFIG_KEY_SARIF} }
+
+func (sarifOutputRenderer) Render(data workflow.Data, writer *bytes.Buffer) error {
+	payload, ok := data.GetPayload().([]byte)
+	if !ok {
+		return fmt.Errorf("unsupported sarif payload type")
+	}
+	_, err := writer.Write(payload)
+	return err
+}
+
+// textOutputRenderer is the fallback renderer for text/plain and otherwise
+// unrecognized payloads. Unlike the other renderers it is not registered in
+// outputRenderers, since it isn't selected by matching a mime type substring
+// but used whenever lookupRenderer finds nothing more specific.
+type textOutputRenderer struct{}
+
+func (textOutputRenderer) MimeTypes() []string { return []string{OUTPUT_CONFIG_KEY_TEXT} }
+
+func (textOutputRenderer) Render(data workflow.Data, writer *bytes.Buffer) error {
+	payload, typeCastSuccessful := data.GetPayload().([]byte)
+	if typeCastSuccessful {
+		_, err := writer.Write(payload)
+		return err
+	}
+
+	payloadAsString, typeCastSuccessful := data.GetPayload().(string)
+	if !typeCastSuccessful {
+		return fmt.Errorf("Unsupported output type: %s", data.GetContentType())
+	}
+
+	_, err := writer.WriteString(payloadAsString)
+	return err
+}
+
+// OutputSink describes one destination that rendered output for a given
+// format should be written to, as parsed from a single --output flag value.
+type OutputSink struct {
+	Format      string
+	Destination string
+}
+
+// parseOutputSinks parses a list of "format:destination" specs, as supplied
+// via repeated --output flags, into OutputSink values. A spec without a ":"
+// or with an empty format is rejected. destination "-" (or empty, as in
+// "json:") means stdout; anything else is treated as a file path, except for
+// http(s) URLs which are POSTed to.
+func parseOutputSinks(specs []string) ([]OutputSink, error) {
+	sinks := make([]OutputSink, 0, len(specs))
+	for _, spec := range specs {
+		parts := strings.SplitN(spec, ":", 2)
+		if len(parts) != 2 || len(parts[0]) == 0 {
+			return nil, fmt.Errorf("invalid --output value '%s', expected format:destination", spec)
+		}
+		sinks = append(sinks, OutputSink{Format: parts[0], Destination: parts[1]})
+	}
+	return sinks, nil
+}
+
+// writeToSink writes data to the destination described by sink, printing to
+// the console for "-", POSTing to http(s) URLs, and otherwise treating the
+// destination as a file path.
+func writeToSink(outputDestination iUtils.OutputDestination, sink OutputSink, data []byte) error {
+	switch {
+	case len(sink.Destination) == 0 || sink.Destination == "-":
+		outputDestination.Println(string(data))
+		return nil
+	case strings.HasPrefix(sink.Destination, "http://") || strings.HasPrefix(sink.Destination, "https://"):
+		response, err := http.Post(sink.Destination, "application/octet-stream", bytes.NewReader(data))
+		if err != nil {
+			return fmt.Errorf("failed to upload %s output to '%s': %w", sink.Format, sink.Destination, err)
+		}
+		defer response.Body.Close()
+		if response.StatusCode >= 300 {
+			return fmt.Errorf("failed to upload %s output to '%s': unexpected status %d", sink.Format, sink.Destination, response.StatusCode)
+		}
+		return nil
+	default:
+		outputDestination.Remove(sink.Destination)
+		return outputDestination.WriteFile(sink.Destination, data, iUtils.FILEPERM_666)
+	}
+}
+
 // InitOutputWorkflow initializes the output workflow
 // The output workflow is responsible for handling the output destination of workflow data
 // As part of the localworkflows package, it is registered via the localworkflows.Init method
@@ -23,6 +159,11 @@ func InitOutputWorkflow(engine workflow.Engine) error {
 	outputConfig := pflag.NewFlagSet("output", pflag.ExitOnError)
 	outputConfig.Bool(OUTPUT_CONFIG_KEY_JSON, false, "Print json output to console")
 	outputConfig.String(OUTPUT_CONFIG_KEY_JSON_FILE, "", "Write json output to file")
+	outputConfig.Bool(OUTPUT_CONFIG_KEY_SARIF, false, "Print sarif output to console")
+	outputConfig.String(OUTPUT_CONFIG_KEY_SARIF_FILE, "", "Write sarif output to file")
+	outputConfig.String(OUTPUT_CONFIG_KEY_FORMAT, "", "Name of the registered output format to render (e.g. json, sarif); generalizes the --json/--sarif flags")
+	outputConfig.String(OUTPUT_CONFIG_KEY_OUTPUT_FILE, "", "Write the rendered --format output to file; generalizes the --json-file-output/--sarif-file-output flags")
+	outputConfig.StringArray(OUTPUT_CONFIG_KEY_OUTPUT, []string{}, "Render output in the given format and write it to the given destination, as format:destination (e.g. json:-, sarif:results.sarif); can be repeated to fan out to multiple destinations")
 
 	entry, err := engine.Register(WORKFLOWID_OUTPUT_WORKFLOW, workflow.ConfigurationOptionsFromFlagset(outputConfig), outputWorkflowEntryPointImpl)
 	entry.SetVisibility(false)
@@ -30,6 +171,58 @@ func InitOutputWorkflow(engine workflow.Engine) error {
 	return err
 }
 
+// lookupRenderer returns the renderer registered for mimeType, along with the
+// format name it was registered under, or (nil, "") if none matches. Every
+// format registered via RegisterOutputRenderer is a candidate, not just the
+// built-ins, so integrators can add their own (HTML, JUnit, ...) without
+// forking the module. When mimeType contains more than one registered format
+// name (e.g. "application/sarif+json" contains both "sarif" and "json"), the
+// longest match wins, ties broken alphabetically for determinism, so a more
+// specific format is preferred over a more general one it happens to embed.
+func lookupRenderer(mimeType string) (OutputRenderer, string) {
+	var bestRenderer OutputRenderer
+	bestFormat := ""
+	for formatName, renderer := range outputRenderers {
+		if !strings.Contains(mimeType, formatName) {
+			continue
+		}
+		if bestRenderer == nil || len(formatName) > len(bestFormat) || (len(formatName) == len(bestFormat) && formatName < bestFormat) {
+			bestRenderer = renderer
+			bestFormat = formatName
+		}
+	}
+	return bestRenderer, bestFormat
+}
+
+// resolveDestination determines whether the rendered output for formatName
+// should be printed to the console and/or written to a file, honoring both
+// the format-specific legacy flags (--json/--json-file-output, ...) and the
+// generalized --format/--output-file flags. If neither is specified for a
+// format that is actually present in the input, the output is printed to the
+// console by default, preserving prior behavior.
+func resolveDestination(config workflow.Configuration, formatName string, legacyFlag string, legacyFileFlag string) (printToCmd bool, fileDestination string) {
+	if len(legacyFlag) > 0 {
+		printToCmd = config.GetBool(legacyFlag)
+	}
+	if len(legacyFileFlag) > 0 {
+		fileDestination = config.GetString(legacyFileFlag)
+	}
+
+	if config.GetString(OUTPUT_CONFIG_KEY_FORMAT) == formatName {
+		if outputFile := config.GetString(OUTPUT_CONFIG_KEY_OUTPUT_FILE); len(outputFile) > 0 {
+			fileDestination = outputFile
+		} else {
+			printToCmd = true
+		}
+	}
+
+	if !printToCmd && len(fileDestination) == 0 {
+		printToCmd = true
+	}
+
+	return printToCmd, fileDestination
+}
+
 // outputWorkflowEntryPoint defines the output entry point
 // the entry point is called by the engine when the workflow is invoked
 func outputWorkflowEntryPoint(invocation workflow.InvocationContext, input []workflow.Data, outputDestination iUtils.OutputDestination) (output []workflow.Data, err error) {
@@ -39,8 +232,10 @@ func outputWorkflowEntryPoint(invocation workflow.InvocationContext, input []wor
 	config := invocation.GetConfiguration()
 	debugLogger := invocation.GetLogger()
 
-	printJsonToCmd := config.GetBool(OUTPUT_CONFIG_KEY_JSON)
-	writeJsonToFile := config.GetString(OUTPUT_CONFIG_KEY_JSON_FILE)
+	outputSinks, err := parseOutputSinks(config.GetStringSlice(OUTPUT_CONFIG_KEY_OUTPUT))
+	if err != nil {
+		return output, err
+	}
 
 	for i := range input {
 		mimeType := input[i].GetContentType()
@@ -51,39 +246,64 @@ func outputWorkflowEntryPoint(invocation workflow.InvocationContext, input []wor
 
 		debugLogger.Printf("Processing '%s' based on '%s' of type '%s'\n", input[i].GetIdentifier().String(), contentLocation, mimeType)
 
-		if strings.Contains(mimeType, OUTPUT_CONFIG_KEY_JSON) { // handle application/json
-			singleData := input[i].GetPayload().([]byte)
-
-			// if json data is processed but non of the json related output configuration is specified, default printJsonToCmd is enabled
-			if printJsonToCmd == false && len(writeJsonToFile) == 0 {
-				printJsonToCmd = true
-			}
+		renderer, formatName := lookupRenderer(mimeType)
+		if renderer == nil {
+			renderer, formatName = textOutputRenderer{}, OUTPUT_CONFIG_KEY_TEXT
+		}
 
-			if printJsonToCmd {
-				outputDestination.Println(string(singleData))
-			}
+		var rendered bytes.Buffer
+		if renderErr := renderer.Render(input[i], &rendered); renderErr != nil {
+			return output, renderErr
+		}
 
-			if len(writeJsonToFile) > 0 {
-				debugLogger.Printf("Writing '%s' JSON of length %d to '%s'\n", input[i].GetIdentifier().String(), len(singleData), writeJsonToFile)
+		if len(outputSinks) > 0 {
+			matched := false
+			for _, sink := range outputSinks {
+				if sink.Format != formatName {
+					continue
+				}
+				matched = true
 
-				outputDestination.Remove(writeJsonToFile)
-				outputDestination.WriteFile(writeJsonToFile, singleData, iUtils.FILEPERM_666)
-			}
-		} else { // handle text/pain and unknown the same way
-			// try to convert payload to a string
-			singleDataAsString := ""
-			singleData, typeCastSuccessful := input[i].GetPayload().([]byte)
-			if !typeCastSuccessful {
-				singleDataAsString, typeCastSuccessful = input[i].GetPayload().(string)
-				if !typeCastSuccessful {
-					err := fmt.Errorf("Unsupported output type: %s", mimeType)
-					return output, err
+				debugLogger.Printf("Writing '%s' %s of length %d to '%s'\n", input[i].GetIdentifier().String(), formatName, rendered.Len(), sink.Destination)
+				if sinkErr := writeToSink(outputDestination, sink, rendered.Bytes()); sinkErr != nil {
+					return output, sinkErr
 				}
-			} else {
-				singleDataAsString = string(singleData)
 			}
+			if !matched {
+				// formats with no matching --output sink still need somewhere
+				// to go, or they're silently dropped with nothing but a debug
+				// log line - e.g. "--output=sarif:results.sarif" on an
+				// invocation that also emits text/plain log data. Fall back
+				// to the console, preserving the pre-fan-out default.
+				debugLogger.Printf("No --output destination configured for format '%s' of '%s', printing to console\n", formatName, input[i].GetIdentifier().String())
+				outputDestination.Println(rendered.String())
+			}
+			continue
+		}
+
+		// the text fallback format predates --json/--sarif and has no legacy
+		// flags of its own; it must not inherit json's, or a --json-file-output
+		// meant for JSON results data silently swallows (or corrupts) any
+		// text/plain data processed in the same invocation.
+		legacyFlag, legacyFileFlag := "", ""
+		switch formatName {
+		case OUTPUT_CONFIG_KEY_JSON:
+			legacyFlag, legacyFileFlag = OUTPUT_CONFIG_KEY_JSON, OUTPUT_CONFIG_KEY_JSON_FILE
+		case OUTPUT_CONFIG_KEY_SARIF:
+			legacyFlag, legacyFileFlag = OUTPUT_CONFIG_KEY_SARIF, OUTPUT_CONFIG_KEY_SARIF_FILE
+		}
+
+		printToCmd, fileDestination := resolveDestination(config, formatName, legacyFlag, legacyFileFlag)
+
+		if printToCmd {
+			outputDestination.Println(rendered.String())
+		}
+
+		if len(fileDestination) > 0 {
+			debugLogger.Printf("Writing '%s' %s of length %d to '%s'\n", input[i].GetIdentifier().String(), formatName, rendered.Len(), fileDestination)
 
-			outputDestination.Println(singleDataAsString)
+			outputDestination.Remove(fileDestination)
+			outputDestination.WriteFile(fileDestination, rendered.Bytes(), iUtils.FILEPERM_666)
 		}
 	}
 
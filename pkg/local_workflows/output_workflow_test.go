@@ -0,0 +1,108 @@
+package localworkflows
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/snyk/go-application-framework/pkg/workflow"
+	"github.com/stretchr/testify/assert"
+)
+
+// Note: resolveDestination, writeToSink and the *EntryPoint* functions are
+// not covered by the tests in this file, since they take workflow.Configuration /
+// workflow.Data / workflow.InvocationContext / iUtils.OutputDestination as
+// arguments and this package has no fakes for those types. lookupRenderer and
+// parseOutputSinks are pure and covered directly below.
+
+// fakeOutputRenderer is a stand-in for an integrator-supplied renderer added
+// via RegisterOutputRenderer, e.g. for a format this package doesn't know
+// about such as JUnit.
+type fakeOutputRenderer struct{ mimeTypes []string }
+
+func (r fakeOutputRenderer) MimeTypes() []string { return r.mimeTypes }
+
+func (fakeOutputRenderer) Render(data workflow.Data, writer *bytes.Buffer) error { return nil }
+
+func Test_LookupRenderer(t *testing.T) {
+	sarifRenderer, ok := outputRenderers[OUTPUT_CONFIG_KEY_SARIF]
+	assert.True(t, ok)
+	jsonRenderer, ok := outputRenderers[OUTPUT_CONFIG_KEY_JSON]
+	assert.True(t, ok)
+
+	renderer, formatName := lookupRenderer("application/json")
+	assert.Equal(t, jsonRenderer, renderer)
+	assert.Equal(t, OUTPUT_CONFIG_KEY_JSON, formatName)
+
+	renderer, formatName = lookupRenderer("application/sarif+json")
+	assert.Equal(t, sarifRenderer, renderer)
+	assert.Equal(t, OUTPUT_CONFIG_KEY_SARIF, formatName)
+
+	renderer, formatName = lookupRenderer("text/plain")
+	assert.Nil(t, renderer)
+	assert.Equal(t, "", formatName)
+
+	renderer, formatName = lookupRenderer("")
+	assert.Nil(t, renderer)
+	assert.Equal(t, "", formatName)
+}
+
+// Test_LookupRenderer_RegisteredFormat guards against regressing lookupRenderer
+// to only ever consider the built-in formats: a renderer registered via
+// RegisterOutputRenderer for a format this package knows nothing about (e.g.
+// "junit") must be reachable through a matching mime type, since that's the
+// entire point of making RegisterOutputRenderer public.
+func Test_LookupRenderer_RegisteredFormat(t *testing.T) {
+	junitRenderer := fakeOutputRenderer{mimeTypes: []string{"junit"}}
+	RegisterOutputRenderer(junitRenderer)
+	defer delete(outputRenderers, "junit")
+
+	renderer, formatName := lookupRenderer("application/junit+xml")
+	assert.Equal(t, junitRenderer, renderer)
+	assert.Equal(t, "junit", formatName)
+}
+
+// Test_LookupRenderer_PrefersMostSpecificFormat guards the substring-collision
+// tiebreak (longest match wins) that replaced the old fixed priority list.
+func Test_LookupRenderer_PrefersMostSpecificFormat(t *testing.T) {
+	renderer, formatName := lookupRenderer("application/sarif+json")
+	assert.Equal(t, OUTPUT_CONFIG_KEY_SARIF, formatName)
+	assert.Equal(t, outputRenderers[OUTPUT_CONFIG_KEY_SARIF], renderer)
+}
+
+func Test_ParseOutputSinks(t *testing.T) {
+	sinks, err := parseOutputSinks([]string{"json:-", "sarif:results.sarif"})
+	assert.Nil(t, err)
+	assert.Equal(t, []OutputSink{
+		{Format: OUTPUT_CONFIG_KEY_JSON, Destination: "-"},
+		{Format: OUTPUT_CONFIG_KEY_SARIF, Destination: "results.sarif"},
+	}, sinks)
+}
+
+func Test_ParseOutputSinks_Empty(t *testing.T) {
+	sinks, err := parseOutputSinks(nil)
+	assert.Nil(t, err)
+	assert.Len(t, sinks, 0)
+}
+
+func Test_ParseOutputSinks_DestinationContainsColon(t *testing.T) {
+	sinks, err := parseOutputSinks([]string{"json:https://example.com/upload"})
+	assert.Nil(t, err)
+	assert.Equal(t, []OutputSink{
+		{Format: OUTPUT_CONFIG_KEY_JSON, Destination: "https://example.com/upload"},
+	}, sinks)
+}
+
+func Test_ParseOutputSinks_MissingColon(t *testing.T) {
+	_, err := parseOutputSinks([]string{"json"})
+	assert.NotNil(t, err)
+}
+
+func Test_ParseOutputSinks_EmptyFormat(t *testing.T) {
+	_, err := parseOutputSinks([]string{":results.json"})
+	assert.NotNil(t, err)
+}
+
+func Test_ParseOutputSinks_InvalidAmongValid(t *testing.T) {
+	_, err := parseOutputSinks([]string{"json:-", "invalid"})
+	assert.NotNil(t, err)
+}